@@ -0,0 +1,150 @@
+// config.go: resolv.conf-style config file for the syslog field layout
+// author: Mark W. Hunter
+// https://github.com/mark-w-hunter/dnscl
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mark-w-hunter/dnscl/parser"
+)
+
+// appConfig is the active configuration, loaded once at startup by main and
+// overridable by CLI flags such as --source.
+var appConfig Config
+
+// Config holds dnscl's runtime settings, loaded from a resolv.conf-style
+// config file.
+type Config struct {
+	Logfile         string
+	ClientField     int
+	QnameField      int
+	QtypeField      int
+	TimeFormat      string
+	IgnoreViews     []string
+	RDNSServer      string
+	RDNSConcurrency int
+	RDNSTimeoutMS   int
+	RDNSRetries     int
+}
+
+// defaultConfig mirrors the log path and field positions dnscl has always
+// assumed, for deployments with no config file present.
+func defaultConfig() Config {
+	return Config{
+		Logfile:         "/var/log/syslog",
+		ClientField:     5,
+		QnameField:      8,
+		QtypeField:      11,
+		TimeFormat:      parser.TimeFormatSyslog,
+		RDNSConcurrency: rdnsDefaultConcurrency,
+		RDNSTimeoutMS:   int(rdnsDefaultTimeout / time.Millisecond),
+		RDNSRetries:     rdnsDefaultRetries,
+	}
+}
+
+// configPaths returns the config file locations dnscl checks, in order: a
+// per-user override before the system-wide default, the same resolution
+// order resolv.conf-style configs use.
+func configPaths() []string {
+	var paths []string
+	if home, err := os.UserHomeDir(); err == nil {
+		paths = append(paths, filepath.Join(home, ".dnsclrc"))
+	}
+	paths = append(paths, "/etc/dnscl.conf")
+	return paths
+}
+
+// loadConfig reads the first config file found among configPaths, applying
+// its keyword-value pairs on top of defaultConfig. A missing file is not an
+// error; dnscl falls back to its built-in defaults.
+func loadConfig() (Config, error) {
+	config := defaultConfig()
+
+	for _, path := range configPaths() {
+		file, err := os.Open(path)
+		if err != nil {
+			continue
+		}
+		defer file.Close()
+		return parseConfig(file, config)
+	}
+
+	return config, nil
+}
+
+// parseConfig reads line-oriented keyword-value pairs from file, such as
+// "logfile /var/log/named/queries.log" or "client-field 5", applying them
+// on top of config. "#" starts a comment; blank lines are skipped.
+func parseConfig(file *os.File, config Config) (Config, error) {
+	scanner := bufio.NewScanner(file)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			return config, fmt.Errorf("%s: line %d: expected keyword value", file.Name(), lineNum)
+		}
+		keyword, value := fields[0], strings.Join(fields[1:], " ")
+
+		var err error
+		switch keyword {
+		case "logfile":
+			config.Logfile = value
+		case "client-field":
+			config.ClientField, err = strconv.Atoi(value)
+		case "qname-field":
+			config.QnameField, err = strconv.Atoi(value)
+		case "qtype-field":
+			config.QtypeField, err = strconv.Atoi(value)
+		case "timeformat":
+			if value == parser.TimeFormatSyslog || value == parser.TimeFormatRFC3339 {
+				config.TimeFormat = value
+			} else {
+				err = fmt.Errorf("must be %q or %q", parser.TimeFormatSyslog, parser.TimeFormatRFC3339)
+			}
+		case "ignore-view":
+			config.IgnoreViews = append(config.IgnoreViews, value)
+		case "rdns-server":
+			config.RDNSServer = value
+		case "rdns-concurrency":
+			config.RDNSConcurrency, err = strconv.Atoi(value)
+			if err == nil && config.RDNSConcurrency <= 0 {
+				err = fmt.Errorf("must be greater than 0, got %d", config.RDNSConcurrency)
+			}
+		case "rdns-timeout-ms":
+			config.RDNSTimeoutMS, err = strconv.Atoi(value)
+		case "rdns-retries":
+			config.RDNSRetries, err = strconv.Atoi(value)
+		default:
+			return config, fmt.Errorf("%s: line %d: unknown keyword %q", file.Name(), lineNum, keyword)
+		}
+		if err != nil {
+			return config, fmt.Errorf("%s: line %d: invalid %s: %w", file.Name(), lineNum, keyword, err)
+		}
+	}
+
+	return config, scanner.Err()
+}
+
+// ignoresView reports whether config excludes view from results.
+func (c Config) ignoresView(view string) bool {
+	for _, ignored := range c.IgnoreViews {
+		if ignored == view {
+			return true
+		}
+	}
+	return false
+}
@@ -35,14 +35,12 @@ import (
 	"sort"
 	"strings"
 	"time"
-)
 
-const (
-	filename = "/var/log/syslog" // path to syslog file
-	// filename = "/var/log/messages" // path to alternate syslog file
-	wildcard = ""
+	"github.com/mark-w-hunter/dnscl/parser"
 )
 
+const wildcard = ""
+
 // Count is the number of results from a query
 type Count struct {
 	Key   string
@@ -55,7 +53,7 @@ func dnsclIPaddress(ipAddress string) int {
 	domainMap := make(map[string]int)
 	ipAddressSearch := ipAddress + "#"
 
-	syslogFile, err := os.Open(filename)
+	syslogFile, err := resolveLogSource(logSourceFlag).Open()
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -68,16 +66,15 @@ func dnsclIPaddress(ipAddress string) int {
 
 	scanner := bufio.NewScanner(syslogFile)
 	for scanner.Scan() {
-		if strings.Contains(scanner.Text(), "named") && strings.Contains(scanner.Text(), "query:") {
-			if strings.Contains(scanner.Text(), ipAddressSearch) {
-				fields := strings.Fields(scanner.Text())
-				if len(fields) > 12 {
-					domain := fields[8]
-					domainMap[domain]++
-				}
-				lineCount++
-			}
+		if !strings.Contains(scanner.Text(), ipAddressSearch) {
+			continue
 		}
+		event, ok := parser.Parse(scanner.Text())
+		if !ok || appConfig.ignoresView(event.View) {
+			continue
+		}
+		domainMap[event.QueryName]++
+		lineCount++
 	}
 
 	domainMapSorted := sortMap(domainMap)
@@ -103,7 +100,7 @@ func dnsclDomainName(domainName string) int {
 	domainMap := make(map[string]int)
 	domainRegex := regexp.MustCompile("(?i)" + domainName)
 
-	syslogFile, err := os.Open(filename)
+	syslogFile, err := resolveLogSource(logSourceFlag).Open()
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -116,31 +113,33 @@ func dnsclDomainName(domainName string) int {
 
 	scanner := bufio.NewScanner(syslogFile)
 	for scanner.Scan() {
-		if strings.Contains(scanner.Text(), "named") && strings.Contains(scanner.Text(), "query:") {
-			match := domainRegex.MatchString(scanner.Text())
-			if match {
-				fields := strings.Fields(scanner.Text())
-				if len(fields) > 12 {
-					ipAddrFields := strings.Split(fields[5], "#")
-					ipAddr := ipAddrFields[0]
-					domainNameField := fields[8]
-					ipMap[ipAddr]++
-					domainMap[domainNameField]++
-				}
-				lineCount++
-			}
+		if !domainRegex.MatchString(scanner.Text()) {
+			continue
+		}
+		event, ok := parser.Parse(scanner.Text())
+		if !ok || appConfig.ignoresView(event.View) {
+			continue
 		}
+		ipMap[event.ClientIP]++
+		domainMap[event.QueryName]++
+		lineCount++
 	}
 
 	ipMapSorted := sortMap(ipMap)
 	elapsedTime := time.Since(startTime).Seconds()
 
+	ptrNames := resolvePTRNames(ipMapSorted)
+
 	fmt.Println()
 	fmt.Println(domainName, "total queries:", lineCount)
 	fmt.Println("ip addresses:")
 
 	for _, ipAddress := range ipMapSorted {
-		fmt.Printf("%v \t %v\n", ipAddress.Value, ipAddress.Key)
+		if ptrNames == nil {
+			fmt.Printf("%v \t %v\n", ipAddress.Value, ipAddress.Key)
+			continue
+		}
+		fmt.Printf("%v \t %v \t %v\n", ipAddress.Value, ipAddress.Key, ptrNames[ipAddress.Key])
 	}
 
 	domainKeys := make([]string, 0, len(domainMap))
@@ -161,6 +160,170 @@ func dnsclDomainName(domainName string) int {
 	return lineCount
 }
 
+func dnsclQueryType(queryType string) int {
+	startTime := time.Now()
+	lineCount := 0
+	domainMap := make(map[string]int)
+
+	syslogFile, err := resolveLogSource(logSourceFlag).Open()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	defer func() {
+		if err = syslogFile.Close(); err != nil {
+			log.Fatal(err)
+		}
+	}()
+
+	var events []parser.QueryEvent
+	scanner := bufio.NewScanner(syslogFile)
+	for scanner.Scan() {
+		event, ok := parser.Parse(scanner.Text())
+		if !ok || appConfig.ignoresView(event.View) {
+			continue
+		}
+		events = append(events, event)
+	}
+
+	for _, event := range parser.FilterByType(events, queryType) {
+		domainMap[event.QueryName]++
+		lineCount++
+	}
+
+	domainMapSorted := sortMap(domainMap)
+	elapsedTime := time.Since(startTime).Seconds()
+
+	fmt.Println()
+	fmt.Println(queryType, "total queries:", lineCount)
+	fmt.Println("queries:")
+
+	for _, domainName := range domainMapSorted {
+		fmt.Printf("%v \t %v\n", domainName.Value, domainName.Key)
+	}
+
+	fmt.Printf("\nSummary: Searched query type %s and found %d queries for %d domain names.\n", queryType, lineCount, len(domainMap))
+	fmt.Printf("Query time: %.2f seconds\n", elapsedTime)
+	return lineCount
+}
+
+func dnsclFlag(flag string) int {
+	startTime := time.Now()
+	lineCount := 0
+	ipMap := make(map[string]int)
+
+	syslogFile, err := resolveLogSource(logSourceFlag).Open()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	defer func() {
+		if err = syslogFile.Close(); err != nil {
+			log.Fatal(err)
+		}
+	}()
+
+	var events []parser.QueryEvent
+	scanner := bufio.NewScanner(syslogFile)
+	for scanner.Scan() {
+		event, ok := parser.Parse(scanner.Text())
+		if !ok || appConfig.ignoresView(event.View) {
+			continue
+		}
+		events = append(events, event)
+	}
+
+	for _, event := range parser.FilterByFlag(events, flag) {
+		ipMap[event.ClientIP]++
+		lineCount++
+	}
+
+	ipMapSorted := sortMap(ipMap)
+	elapsedTime := time.Since(startTime).Seconds()
+
+	ptrNames := resolvePTRNames(ipMapSorted)
+
+	fmt.Println()
+	fmt.Println(flag, "total queries:", lineCount)
+	fmt.Println("clients:")
+
+	for _, ipAddress := range ipMapSorted {
+		if ptrNames == nil {
+			fmt.Printf("%v \t %v\n", ipAddress.Value, ipAddress.Key)
+			continue
+		}
+		fmt.Printf("%v \t %v \t %v\n", ipAddress.Value, ipAddress.Key, ptrNames[ipAddress.Key])
+	}
+
+	fmt.Printf("\nSummary: Searched flag %s and found %d queries from %d clients.\n", flag, lineCount, len(ipMap))
+	fmt.Printf("Query time: %.2f seconds\n", elapsedTime)
+	return lineCount
+}
+
+// resolvePTRNames annotates counts with PTR records, or returns nil when
+// --no-rdns was passed.
+func resolvePTRNames(counts []Count) map[string]string {
+	if noRDNS {
+		return nil
+	}
+
+	ips := make([]string, 0, len(counts))
+	for _, count := range counts {
+		ips = append(ips, count.Key)
+	}
+
+	server := appConfig.RDNSServer
+	if rdnsServerFlag != "" {
+		server = rdnsServerFlag
+	}
+
+	resolver := NewRDNSResolver(server, appConfig.RDNSConcurrency, time.Duration(appConfig.RDNSTimeoutMS)*time.Millisecond, appConfig.RDNSRetries)
+	return resolver.ResolveAll(ips)
+}
+
+// parseGlobalFlags extracts dnscl's global flags (--source, --no-rdns,
+// --rdns-server) from args, setting their package-level vars, regardless of
+// where they appear relative to the subcommand — "dnscl --source f.log ip
+// 1.2.3.4" and "dnscl ip 1.2.3.4 --source f.log" are equivalent. It returns
+// args with the recognized global flags (and their values) removed, leaving
+// the subcommand and its own arguments/flags (like --follow) untouched.
+func parseGlobalFlags(args []string) []string {
+	var rest []string
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--no-rdns":
+			noRDNS = true
+		case "--source":
+			if i+1 >= len(args) {
+				log.Fatal("usage: --source <path|journald|journald:<unit>|glob|->")
+			}
+			logSourceFlag = args[i+1]
+			i++
+		case "--rdns-server":
+			if i+1 >= len(args) {
+				log.Fatal("usage: --rdns-server <host>")
+			}
+			rdnsServerFlag = args[i+1]
+			i++
+		default:
+			rest = append(rest, args[i])
+		}
+	}
+	return rest
+}
+
+// hasArg reports whether any of names appears in args.
+func hasArg(args []string, names ...string) bool {
+	for _, arg := range args {
+		for _, name := range names {
+			if arg == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func sortMap(mapUnsorted map[string]int) []Count {
 	var mapSorted []Count
 
@@ -179,12 +342,68 @@ func menu() {
 	fmt.Println("Enter 0 to exit")
 	fmt.Println("Enter 1 to search ip")
 	fmt.Println("Enter 2 to search domain")
+	fmt.Println("Enter 3 to search query type")
+	fmt.Println("Enter 4 to search flag")
+	fmt.Println("Enter 5 to view response/RPZ analytics")
 }
 
 func main() {
 	var choice int
 
-	if len(os.Args) < 2 {
+	config, err := loadConfig()
+	if err != nil {
+		log.Fatal(err)
+	}
+	appConfig = config
+	parser.SetFieldLayout(parser.FieldLayout{
+		ClientField: appConfig.ClientField,
+		QnameField:  appConfig.QnameField,
+		QtypeField:  appConfig.QtypeField,
+	})
+	parser.SetTimeFormat(appConfig.TimeFormat)
+
+	args := parseGlobalFlags(os.Args[1:])
+
+	if len(args) >= 1 {
+		switch args[0] {
+		case "serve":
+			addr := defaultServeAddr
+			if len(args) >= 2 {
+				addr = args[1]
+			}
+			if err := dnsclServe(addr); err != nil {
+				log.Fatal(err)
+			}
+			return
+		case "ip":
+			if len(args) < 2 {
+				log.Fatal("usage: dnscl [--source ...] [--no-rdns] [--rdns-server ...] ip <address> [--follow]")
+			}
+			if hasArg(args, "--follow", "-f") {
+				dnsclIPaddressFollow(args[1])
+			} else {
+				dnsclIPaddress(args[1])
+			}
+			return
+		case "domain":
+			if len(args) < 2 {
+				log.Fatal("usage: dnscl [--source ...] [--no-rdns] [--rdns-server ...] domain <name> [--follow]")
+			}
+			if hasArg(args, "--follow", "-f") {
+				dnsclDomainNameFollow(args[1])
+			} else {
+				dnsclDomainName(args[1])
+			}
+			return
+		case "responses":
+			dnsclResponses()
+			return
+		default:
+			log.Fatalf("dnscl: unrecognized command %q", args[0])
+		}
+	}
+
+	if len(args) < 1 {
 		for {
 			menu()
 			input := wildcard
@@ -204,6 +423,16 @@ func main() {
 					fmt.Print("domain name: ")
 					fmt.Scanln(&input)
 					dnsclDomainName(input)
+				case 3:
+					fmt.Print("query type: ")
+					fmt.Scanln(&input)
+					dnsclQueryType(input)
+				case 4:
+					fmt.Print("flag: ")
+					fmt.Scanln(&input)
+					dnsclFlag(input)
+				case 5:
+					dnsclResponses()
 				default:
 					fmt.Println("Invalid choice, try again.")
 				}
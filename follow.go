@@ -0,0 +1,166 @@
+// follow.go: live tail mode with incremental aggregation
+// author: Mark W. Hunter
+// https://github.com/mark-w-hunter/dnscl
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/mark-w-hunter/dnscl/parser"
+)
+
+// followPollInterval is how often a tailer checks for new data once it has
+// caught up to EOF.
+const followPollInterval = 500 * time.Millisecond
+
+// fileTailer incrementally reads lines appended to a file, detecting log
+// rotation by comparing the file's on-disk identity (inode and device on
+// Unix) against the path it was opened from.
+type fileTailer struct {
+	path   string
+	file   *os.File
+	reader *bufio.Reader
+	info   os.FileInfo
+}
+
+func newFileTailer(path string) (*fileTailer, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	if _, err := file.Seek(0, os.SEEK_END); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return &fileTailer{path: path, file: file, reader: bufio.NewReader(file), info: info}, nil
+}
+
+// Lines streams newly appended lines until stop is closed.
+func (t *fileTailer) Lines(stop <-chan struct{}) <-chan string {
+	lines := make(chan string)
+
+	go func() {
+		defer close(lines)
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+
+			line, err := t.reader.ReadString('\n')
+			if err != nil {
+				t.checkRotation()
+				time.Sleep(followPollInterval)
+				continue
+			}
+
+			select {
+			case lines <- strings.TrimRight(line, "\n"):
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	return lines
+}
+
+// checkRotation reopens the tailed path if it now refers to a different
+// file than the one we have open, which is how logrotate handles rotation.
+func (t *fileTailer) checkRotation() {
+	info, err := os.Stat(t.path)
+	if err != nil || os.SameFile(info, t.info) {
+		return
+	}
+
+	file, err := os.Open(t.path)
+	if err != nil {
+		return
+	}
+	t.file.Close()
+	t.file = file
+	t.reader = bufio.NewReader(file)
+	t.info = info
+}
+
+// dnsclIPaddressFollow tails the configured log source and keeps the top-N domain counters
+// for ipAddress updated in place on the terminal, the --follow counterpart
+// to dnsclIPaddress.
+func dnsclIPaddressFollow(ipAddress string) {
+	domainMap := make(map[string]int)
+	ipAddressSearch := ipAddress + "#"
+
+	stop := make(chan struct{})
+	defer close(stop)
+
+	lines, err := resolveFollowSource(logSourceFlag, stop)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for line := range lines {
+		if !strings.Contains(line, ipAddressSearch) {
+			continue
+		}
+		event, ok := parser.Parse(line)
+		if !ok || appConfig.ignoresView(event.View) {
+			continue
+		}
+		domainMap[event.QueryName]++
+		printFollowSummary(ipAddress, domainMap)
+	}
+}
+
+// dnsclDomainNameFollow tails the configured log source and keeps the top-N client counters
+// for domainName updated in place on the terminal, the --follow counterpart
+// to dnsclDomainName.
+func dnsclDomainNameFollow(domainName string) {
+	ipMap := make(map[string]int)
+	domainRegex := regexp.MustCompile("(?i)" + domainName)
+
+	stop := make(chan struct{})
+	defer close(stop)
+
+	lines, err := resolveFollowSource(logSourceFlag, stop)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for line := range lines {
+		if !domainRegex.MatchString(line) {
+			continue
+		}
+		event, ok := parser.Parse(line)
+		if !ok || appConfig.ignoresView(event.View) {
+			continue
+		}
+		ipMap[event.ClientIP]++
+		printFollowSummary(domainName, ipMap)
+	}
+}
+
+// printFollowSummary redraws the terminal with the current top-N counters.
+func printFollowSummary(label string, counts map[string]int) {
+	countsSorted := sortMap(counts)
+
+	fmt.Print("\033[H\033[2J")
+	fmt.Println("dnscl --follow:", label)
+	fmt.Println()
+	for _, count := range countsSorted {
+		fmt.Printf("%v \t %v\n", count.Value, count.Key)
+	}
+}
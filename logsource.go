@@ -0,0 +1,297 @@
+// logsource.go: pluggable log sources (plain files, rotated archives,
+// glob patterns, stdin, and systemd journald)
+// author: Mark W. Hunter
+// https://github.com/mark-w-hunter/dnscl
+
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// logSourceFlag is set from the --source flag; it overrides $DNSCL_LOG and
+// the configured logfile when non-empty.
+var logSourceFlag string
+
+// LogSource provides read access to raw BIND query log data, regardless of
+// whether it lives in a plain file, a rotated archive, stdin, or journald.
+type LogSource interface {
+	Open() (io.ReadCloser, error)
+}
+
+// followableSource is implemented by LogSources that can stream newly
+// appended lines rather than read once to EOF, the --follow counterpart to
+// Open. Sources where tailing doesn't make sense (a compressed rotated
+// file, a glob of several files, stdin) don't implement it.
+type followableSource interface {
+	Follow(stop <-chan struct{}) (<-chan string, error)
+}
+
+// fileSource reads a single plain-text log file.
+type fileSource struct {
+	path string
+}
+
+func (s fileSource) Open() (io.ReadCloser, error) {
+	return os.Open(s.path)
+}
+
+// Follow tails the file from its current end with a fileTailer, picking up
+// logrotate rotations.
+func (s fileSource) Follow(stop <-chan struct{}) (<-chan string, error) {
+	tailer, err := newFileTailer(s.path)
+	if err != nil {
+		return nil, err
+	}
+	return tailer.Lines(stop), nil
+}
+
+// gzipSource reads a single gzip-compressed rotated log file.
+type gzipSource struct {
+	path string
+}
+
+func (s gzipSource) Open() (io.ReadCloser, error) {
+	file, err := os.Open(s.path)
+	if err != nil {
+		return nil, err
+	}
+	gzReader, err := gzip.NewReader(file)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &gzipReadCloser{gzReader: gzReader, file: file}, nil
+}
+
+type gzipReadCloser struct {
+	gzReader *gzip.Reader
+	file     *os.File
+}
+
+func (g *gzipReadCloser) Read(p []byte) (int, error) { return g.gzReader.Read(p) }
+
+func (g *gzipReadCloser) Close() error {
+	g.gzReader.Close()
+	return g.file.Close()
+}
+
+// xzSource reads a single xz-compressed rotated log file by shelling out to
+// the xz command line tool, since the standard library has no xz decoder.
+type xzSource struct {
+	path string
+}
+
+func (s xzSource) Open() (io.ReadCloser, error) {
+	return cmdSource("xz", "-dc", s.path)
+}
+
+// journaldSource reads named's journald unit via journalctl.
+type journaldSource struct {
+	unit string
+}
+
+func (s journaldSource) unitName() string {
+	if s.unit == "" {
+		return "named"
+	}
+	return s.unit
+}
+
+// Open dumps the unit's existing journal entries and exits; it does not
+// follow. Use Follow for a --follow-style live stream.
+func (s journaldSource) Open() (io.ReadCloser, error) {
+	return cmdSource("journalctl", "-u", s.unitName(), "-o", "short-iso", "--output-fields=MESSAGE")
+}
+
+// Follow streams the unit's journal entries as they are logged.
+func (s journaldSource) Follow(stop <-chan struct{}) (<-chan string, error) {
+	rc, err := cmdSource("journalctl", "-u", s.unitName(), "-o", "short-iso", "--output-fields=MESSAGE", "-f")
+	if err != nil {
+		return nil, err
+	}
+
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
+		defer rc.Close()
+
+		scanner := bufio.NewScanner(rc)
+		for scanner.Scan() {
+			select {
+			case lines <- scanner.Text():
+			case <-stop:
+				return
+			}
+		}
+	}()
+	return lines, nil
+}
+
+// cmdSource runs name with args and returns its stdout as a ReadCloser that
+// also reaps the subprocess on Close.
+func cmdSource(name string, args ...string) (io.ReadCloser, error) {
+	cmd := exec.Command(name, args...)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	return &cmdReadCloser{cmd: cmd, stdout: stdout}, nil
+}
+
+type cmdReadCloser struct {
+	cmd    *exec.Cmd
+	stdout io.ReadCloser
+}
+
+func (c *cmdReadCloser) Read(p []byte) (int, error) { return c.stdout.Read(p) }
+
+func (c *cmdReadCloser) Close() error {
+	stdoutErr := c.stdout.Close()
+
+	killErr := c.cmd.Process.Kill()
+	// Wait reaps the child so it doesn't linger as a zombie; its error (the
+	// "killed" exit status, or nil if it had already exited on its own) isn't
+	// a real Close failure, so it's deliberately discarded.
+	c.cmd.Wait()
+
+	if stdoutErr != nil {
+		return stdoutErr
+	}
+	if killErr != nil && !errors.Is(killErr, os.ErrProcessDone) {
+		return killErr
+	}
+	return nil
+}
+
+// globSource merges multiple rotated files matching a glob pattern,
+// oldest-first by modification time.
+type globSource struct {
+	pattern string
+}
+
+func (s globSource) Open() (io.ReadCloser, error) {
+	matches, err := filepath.Glob(s.pattern)
+	if err != nil {
+		return nil, err
+	}
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("no files matched pattern %q", s.pattern)
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		infoI, errI := os.Stat(matches[i])
+		infoJ, errJ := os.Stat(matches[j])
+		if errI != nil || errJ != nil {
+			return matches[i] < matches[j]
+		}
+		return infoI.ModTime().Before(infoJ.ModTime())
+	})
+
+	var readers []io.Reader
+	var closers []io.Closer
+	for _, match := range matches {
+		reader, err := sourceForPath(match).Open()
+		if err != nil {
+			for _, closer := range closers {
+				closer.Close()
+			}
+			return nil, err
+		}
+		readers = append(readers, reader)
+		closers = append(closers, reader)
+	}
+
+	return &multiReadCloser{Reader: io.MultiReader(readers...), closers: closers}, nil
+}
+
+type multiReadCloser struct {
+	io.Reader
+	closers []io.Closer
+}
+
+func (m *multiReadCloser) Close() error {
+	var firstErr error
+	for _, closer := range m.closers {
+		if err := closer.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// stdinSource reads from standard input.
+type stdinSource struct{}
+
+func (s stdinSource) Open() (io.ReadCloser, error) {
+	return os.Stdin, nil
+}
+
+// sourceForPath auto-detects the LogSource implementation for path based on
+// its extension.
+func sourceForPath(path string) LogSource {
+	switch filepath.Ext(path) {
+	case ".gz":
+		return gzipSource{path: path}
+	case ".xz":
+		return xzSource{path: path}
+	default:
+		return fileSource{path: path}
+	}
+}
+
+// newLogSource resolves a --source flag value (or $DNSCL_LOG) into a
+// LogSource. Recognized forms: a plain path, a glob pattern containing "*",
+// "-" for stdin, and "journald" or "journald:<unit>" for systemd journald.
+func newLogSource(source string) LogSource {
+	switch {
+	case source == "-":
+		return stdinSource{}
+	case source == "journald":
+		return journaldSource{}
+	case strings.HasPrefix(source, "journald:"):
+		return journaldSource{unit: strings.TrimPrefix(source, "journald:")}
+	case strings.Contains(source, "*"):
+		return globSource{pattern: source}
+	default:
+		return sourceForPath(source)
+	}
+}
+
+// resolveLogSource returns the LogSource configured via --source, falling
+// back to $DNSCL_LOG and then the configured logfile.
+func resolveLogSource(flagValue string) LogSource {
+	if flagValue != "" {
+		return newLogSource(flagValue)
+	}
+	if envValue := os.Getenv("DNSCL_LOG"); envValue != "" {
+		return newLogSource(envValue)
+	}
+	return newLogSource(appConfig.Logfile)
+}
+
+// resolveFollowSource resolves the same --source/$DNSCL_LOG/logfile
+// configuration as resolveLogSource, but into a stream of newly appended
+// lines, the --follow counterpart. It errors if the resolved source (e.g.
+// a glob pattern, a compressed rotated file, or stdin) doesn't support
+// following.
+func resolveFollowSource(flagValue string, stop <-chan struct{}) (<-chan string, error) {
+	source := resolveLogSource(flagValue)
+	follower, ok := source.(followableSource)
+	if !ok {
+		return nil, fmt.Errorf("--follow is not supported for this log source")
+	}
+	return follower.Follow(stop)
+}
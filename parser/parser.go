@@ -0,0 +1,260 @@
+// Package parser turns raw BIND named query log lines into structured
+// QueryEvent values, replacing the positional fields[8]-style indexing the
+// rest of dnscl used to do inline.
+// author: Mark W. Hunter
+// https://github.com/mark-w-hunter/dnscl
+package parser
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// QueryEvent is a single structured BIND query log entry.
+type QueryEvent struct {
+	Time       time.Time
+	ClientIP   string
+	ClientPort string
+	View       string
+	QueryName  string
+	QueryClass string
+	QueryType  string
+	Flags      string
+}
+
+// FieldLayout is the fixed field positions Parse falls back to when a line
+// contains "query:" but not as a whitespace-separated field of its own, for
+// BIND deployments that log in a different template than the default.
+type FieldLayout struct {
+	ClientField int
+	QnameField  int
+	QtypeField  int
+}
+
+var fieldLayout = FieldLayout{ClientField: 5, QnameField: 8, QtypeField: 11}
+
+// SetFieldLayout overrides the fixed field positions used as a fallback.
+func SetFieldLayout(layout FieldLayout) {
+	fieldLayout = layout
+}
+
+// Recognized TimeFormat keywords for SetTimeFormat.
+const (
+	TimeFormatSyslog  = "syslog"
+	TimeFormatRFC3339 = "rfc3339"
+)
+
+var timeFormat = TimeFormatSyslog
+
+// SetTimeFormat overrides the timestamp layout Parse expects lines to start
+// with. format must be TimeFormatSyslog (the default "Mon Day HH:MM:SS"
+// syslog prefix, one leading field per component, year assumed to be the
+// current year) or TimeFormatRFC3339 (a single RFC 3339 field, e.g. the
+// short-iso timestamps journalctl emits). format is ignored if empty.
+func SetTimeFormat(format string) {
+	if format == "" {
+		return
+	}
+	timeFormat = format
+}
+
+// timestampFieldCount reports how many leading whitespace-separated fields
+// the configured TimeFormat's timestamp occupies.
+func timestampFieldCount() int {
+	if timeFormat == TimeFormatRFC3339 {
+		return 1
+	}
+	return 3
+}
+
+// Parse parses a single BIND named query log line into a QueryEvent. It
+// reports false if line is not a query log line or is malformed. Column
+// positions have shifted across BIND 9.11/9.16/9.18 query-log templates, so
+// Parse locates fields by the "query:" token rather than assuming a fixed
+// position, falling back to FieldLayout when "query:" is not a field of its
+// own. Lines that do not contain "query:" at all are never parsed.
+func Parse(line string) (QueryEvent, bool) {
+	if !strings.Contains(line, "named") || !strings.Contains(line, "query:") {
+		return QueryEvent{}, false
+	}
+
+	fields := strings.Fields(line)
+	if len(fields) <= timestampFieldCount() {
+		return QueryEvent{}, false
+	}
+
+	queryIndex := indexOf(fields, "query:")
+	if queryIndex == -1 {
+		return parseFixedLayout(fields)
+	}
+	if queryIndex+3 >= len(fields) {
+		return QueryEvent{}, false
+	}
+
+	timestamp, err := parseLeadingTimestamp(fields)
+	if err != nil {
+		return QueryEvent{}, false
+	}
+
+	client := findClientField(fields[:queryIndex])
+	if client == "" {
+		return QueryEvent{}, false
+	}
+	clientIP, clientPort := splitClientField(client)
+
+	event := QueryEvent{
+		Time:       timestamp,
+		ClientIP:   clientIP,
+		ClientPort: clientPort,
+		View:       viewName(fields, queryIndex),
+		QueryName:  fields[queryIndex+1],
+		QueryClass: fields[queryIndex+2],
+		QueryType:  fields[queryIndex+3],
+	}
+	if queryIndex+4 < len(fields) {
+		event.Flags = fields[queryIndex+4]
+	}
+	return event, true
+}
+
+// parseFixedLayout parses fields using the configured FieldLayout, for log
+// lines whose "query:" text is not a separate field (e.g. run together with
+// a neighbouring token). It is not reached for lines without "query:".
+func parseFixedLayout(fields []string) (QueryEvent, bool) {
+	maxField := fieldLayout.ClientField
+	for _, field := range []int{fieldLayout.QnameField, fieldLayout.QtypeField} {
+		if field > maxField {
+			maxField = field
+		}
+	}
+	if maxField >= len(fields) {
+		return QueryEvent{}, false
+	}
+
+	timestamp, err := parseLeadingTimestamp(fields)
+	if err != nil {
+		return QueryEvent{}, false
+	}
+
+	clientIP, clientPort := splitClientField(fields[fieldLayout.ClientField])
+
+	return QueryEvent{
+		Time:       timestamp,
+		ClientIP:   clientIP,
+		ClientPort: clientPort,
+		QueryName:  fields[fieldLayout.QnameField],
+		QueryType:  fields[fieldLayout.QtypeField],
+	}, true
+}
+
+// findClientField returns the "ip#port" token among fields, which is the
+// client address BIND logs before the query name — its position shifts
+// depending on whether @<pointer> and view tokens are present, so it is
+// located by shape rather than by a fixed index.
+func findClientField(fields []string) string {
+	for _, field := range fields {
+		if strings.Contains(field, "#") && !strings.HasPrefix(field, "(") {
+			return field
+		}
+	}
+	return ""
+}
+
+func splitClientField(field string) (ip string, port string) {
+	ipAddrFields := strings.Split(field, "#")
+	ip = ipAddrFields[0]
+	if len(ipAddrFields) > 1 {
+		port = ipAddrFields[1]
+	}
+	return ip, port
+}
+
+func indexOf(fields []string, value string) int {
+	for i, field := range fields {
+		if field == value {
+			return i
+		}
+	}
+	return -1
+}
+
+// viewName returns the BIND view name preceding the "query:" token, e.g.
+// "view internal: query: ..." -> "internal". It returns "" when the log
+// line has no view (the common case for single-view servers).
+func viewName(fields []string, queryIndex int) string {
+	for i := 0; i < queryIndex-1; i++ {
+		if fields[i] == "view" {
+			return strings.TrimSuffix(fields[i+1], ":")
+		}
+	}
+	return ""
+}
+
+// rfc3339NoColonOffset matches the timestamps journalctl's "short-iso"
+// output format produces, e.g. "2026-07-26T08:00:01+0000" — ISO 8601, but
+// with a numeric offset rather than the colon-delimited one time.RFC3339
+// requires.
+const rfc3339NoColonOffset = "2006-01-02T15:04:05Z0700"
+
+// parseLeadingTimestamp parses the timestamp occupying fields[:timestampFieldCount()]
+// according to the configured TimeFormat.
+func parseLeadingTimestamp(fields []string) (time.Time, error) {
+	if timeFormat == TimeFormatRFC3339 {
+		if t, err := time.Parse(time.RFC3339, fields[0]); err == nil {
+			return t, nil
+		}
+		return time.Parse(rfc3339NoColonOffset, fields[0])
+	}
+	return parseSyslogTimestamp(fields[0], fields[1], fields[2])
+}
+
+// parseSyslogTimestamp parses the "Mon Day HH:MM:SS" timestamp syslog
+// prefixes lines with. Syslog omits the year, so the current year is
+// assumed.
+func parseSyslogTimestamp(month, day, clockTime string) (time.Time, error) {
+	const layout = "2006 Jan 2 15:04:05"
+	return time.Parse(layout, fmt.Sprintf("%d %s %s %s", time.Now().Year(), month, day, clockTime))
+}
+
+// CountByType aggregates events by QueryType.
+func CountByType(events []QueryEvent) map[string]int {
+	counts := make(map[string]int)
+	for _, event := range events {
+		counts[event.QueryType]++
+	}
+	return counts
+}
+
+// CountByFlag aggregates events by Flags.
+func CountByFlag(events []QueryEvent) map[string]int {
+	counts := make(map[string]int)
+	for _, event := range events {
+		counts[event.Flags]++
+	}
+	return counts
+}
+
+// FilterByType returns events whose QueryType matches queryType
+// (case-insensitive), e.g. "ANY" for FilterByType(events, "any").
+func FilterByType(events []QueryEvent, queryType string) []QueryEvent {
+	filtered := make([]QueryEvent, 0, len(events))
+	for _, event := range events {
+		if strings.EqualFold(event.QueryType, queryType) {
+			filtered = append(filtered, event)
+		}
+	}
+	return filtered
+}
+
+// FilterByFlag returns events whose Flags match flag exactly, e.g. "T" for
+// truncated responses.
+func FilterByFlag(events []QueryEvent, flag string) []QueryEvent {
+	filtered := make([]QueryEvent, 0, len(events))
+	for _, event := range events {
+		if event.Flags == flag {
+			filtered = append(filtered, event)
+		}
+	}
+	return filtered
+}
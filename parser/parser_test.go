@@ -0,0 +1,149 @@
+package parser
+
+import (
+	"testing"
+	"time"
+)
+
+// Sample lines are drawn from real default-install query-log templates
+// across BIND versions; column positions shift between them, which is the
+// reason Parse locates fields by the "query:" token instead of by index.
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name          string
+		line          string
+		wantOK        bool
+		wantClientIP  string
+		wantQueryName string
+		wantQueryType string
+		wantFlags     string
+	}{
+		{
+			name:          "bind 9.11 no view",
+			line:          "Jun 14 08:00:01 ns1 named[1234]: client 192.0.2.10#53421 (example.com): query: example.com IN A + (198.51.100.1)",
+			wantOK:        true,
+			wantClientIP:  "192.0.2.10",
+			wantQueryName: "example.com",
+			wantQueryType: "A",
+			wantFlags:     "+",
+		},
+		{
+			name:          "bind 9.16 with view",
+			line:          "Jun 14 08:00:02 ns1 named[1234]: client @0x7f 192.0.2.11#40211 (mail.example.com): view internal: query: mail.example.com IN AAAA +E (198.51.100.1)",
+			wantOK:        true,
+			wantClientIP:  "192.0.2.11",
+			wantQueryName: "mail.example.com",
+			wantQueryType: "AAAA",
+			wantFlags:     "+E",
+		},
+		{
+			name:          "bind 9.18 ptr query",
+			line:          "Jun 14 08:00:03 ns1 named[5678]: client @0x7f 192.0.2.12#61234 (12.2.0.192.in-addr.arpa): view external: query: 12.2.0.192.in-addr.arpa IN PTR +TD (198.51.100.1)",
+			wantOK:        true,
+			wantClientIP:  "192.0.2.12",
+			wantQueryName: "12.2.0.192.in-addr.arpa",
+			wantQueryType: "PTR",
+			wantFlags:     "+TD",
+		},
+		{
+			name:   "non query line ignored",
+			line:   "Jun 14 08:00:04 ns1 named[1234]: zone example.com/IN: loaded serial 2026010100",
+			wantOK: false,
+		},
+		{
+			name:   "non named line ignored",
+			line:   "Jun 14 08:00:05 ns1 sshd[999]: Accepted publickey for root",
+			wantOK: false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			event, ok := Parse(test.line)
+			if ok != test.wantOK {
+				t.Fatalf("Parse() ok = %v, want %v", ok, test.wantOK)
+			}
+			if !test.wantOK {
+				return
+			}
+			if event.ClientIP != test.wantClientIP {
+				t.Errorf("ClientIP = %q, want %q", event.ClientIP, test.wantClientIP)
+			}
+			if event.QueryName != test.wantQueryName {
+				t.Errorf("QueryName = %q, want %q", event.QueryName, test.wantQueryName)
+			}
+			if event.QueryType != test.wantQueryType {
+				t.Errorf("QueryType = %q, want %q", event.QueryType, test.wantQueryType)
+			}
+			if event.Flags != test.wantFlags {
+				t.Errorf("Flags = %q, want %q", event.Flags, test.wantFlags)
+			}
+		})
+	}
+}
+
+func TestParseRFC3339TimeFormat(t *testing.T) {
+	SetTimeFormat(TimeFormatRFC3339)
+	defer SetTimeFormat(TimeFormatSyslog)
+
+	tests := []struct {
+		name string
+		line string
+	}{
+		{
+			name: "colon offset",
+			line: "2026-06-14T08:00:01+00:00 ns1 named[1234]: client 192.0.2.10#53421 (example.com): query: example.com IN A + (198.51.100.1)",
+		},
+		{
+			// journalctl's "-o short-iso" output format, which is what
+			// logsource.go's journaldSource produces.
+			name: "journalctl short-iso offset",
+			line: "2026-06-14T08:00:01+0000 ns1 named[1234]: client 192.0.2.10#53421 (example.com): query: example.com IN A + (198.51.100.1)",
+		},
+	}
+
+	want := time.Date(2026, time.June, 14, 8, 0, 1, 0, time.UTC)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			event, ok := Parse(test.line)
+			if !ok {
+				t.Fatalf("Parse() ok = false, want true")
+			}
+			if !event.Time.Equal(want) {
+				t.Errorf("Time = %v, want %v", event.Time, want)
+			}
+			if event.QueryName != "example.com" {
+				t.Errorf("QueryName = %q, want %q", event.QueryName, "example.com")
+			}
+		})
+	}
+}
+
+func TestFilterByType(t *testing.T) {
+	events := []QueryEvent{
+		{QueryName: "a.example.com", QueryType: "A"},
+		{QueryName: "b.example.com", QueryType: "ANY"},
+		{QueryName: "c.example.com", QueryType: "any"},
+	}
+
+	filtered := FilterByType(events, "ANY")
+	if len(filtered) != 2 {
+		t.Fatalf("FilterByType() returned %d events, want 2", len(filtered))
+	}
+}
+
+func TestCountByFlag(t *testing.T) {
+	events := []QueryEvent{
+		{Flags: "+"},
+		{Flags: "+E"},
+		{Flags: "+"},
+	}
+
+	counts := CountByFlag(events)
+	if counts["+"] != 2 {
+		t.Errorf("counts[+] = %d, want 2", counts["+"])
+	}
+	if counts["+E"] != 1 {
+		t.Errorf("counts[+E] = %d, want 1", counts["+E"])
+	}
+}
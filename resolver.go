@@ -0,0 +1,126 @@
+// resolver.go: concurrent reverse-DNS enrichment for client IPs
+// author: Mark W. Hunter
+// https://github.com/mark-w-hunter/dnscl
+
+package main
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	rdnsDefaultConcurrency = 10
+	rdnsDefaultTimeout     = 2 * time.Second
+	rdnsDefaultRetries     = 1
+)
+
+// noRDNS disables PTR enrichment when set from the --no-rdns flag.
+var noRDNS bool
+
+// rdnsServerFlag overrides the configured rdns-server when set from the
+// --rdns-server flag.
+var rdnsServerFlag string
+
+// RDNSResolver resolves client IPs to PTR records using a worker pool of
+// goroutines, similar to the massrdns approach: lookups are distributed
+// over a channel, rate-limited only by concurrency.
+type RDNSResolver struct {
+	resolver    *net.Resolver
+	concurrency int
+	timeout     time.Duration
+	retries     int
+
+	mu       sync.Mutex
+	failures int
+}
+
+// NewRDNSResolver returns a resolver that queries upstream (or the system
+// default resolver when upstream is empty) with the given concurrency,
+// per-lookup timeout, and retry count.
+func NewRDNSResolver(upstream string, concurrency int, timeout time.Duration, retries int) *RDNSResolver {
+	resolver := net.DefaultResolver
+	if upstream != "" {
+		resolver = &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+				var dialer net.Dialer
+				return dialer.DialContext(ctx, network, net.JoinHostPort(upstream, "53"))
+			},
+		}
+	}
+
+	return &RDNSResolver{
+		resolver:    resolver,
+		concurrency: concurrency,
+		timeout:     timeout,
+		retries:     retries,
+	}
+}
+
+// ResolveAll resolves every ip in ips to its PTR name using a pool of
+// r.concurrency goroutines, and returns the results keyed by ip. IPs that
+// fail to resolve after retries are omitted.
+func (r *RDNSResolver) ResolveAll(ips []string) map[string]string {
+	results := make(map[string]string)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	jobs := make(chan string)
+	for i := 0; i < r.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ip := range jobs {
+				name, ok := r.resolve(ip)
+				if !ok {
+					continue
+				}
+				mu.Lock()
+				results[ip] = name
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for _, ip := range ips {
+		jobs <- ip
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results
+}
+
+// Failures returns the number of IPs that failed to resolve after retries.
+func (r *RDNSResolver) Failures() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.failures
+}
+
+func (r *RDNSResolver) resolve(ip string) (string, bool) {
+	var name string
+	var err error
+	for attempt := 0; attempt <= r.retries; attempt++ {
+		ctx, cancel := context.WithTimeout(context.Background(), r.timeout)
+		var names []string
+		names, err = r.resolver.LookupAddr(ctx, ip)
+		cancel()
+		if err == nil && len(names) > 0 {
+			name = strings.TrimSuffix(names[0], ".")
+			break
+		}
+	}
+
+	if err != nil {
+		r.mu.Lock()
+		r.failures++
+		r.mu.Unlock()
+	}
+
+	return name, name != ""
+}
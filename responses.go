@@ -0,0 +1,158 @@
+// responses.go: NXDOMAIN / RPZ / response-code analytics
+// author: Mark W. Hunter
+// https://github.com/mark-w-hunter/dnscl
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/mark-w-hunter/dnscl/parser"
+)
+
+// responseCategory buckets a raw named log line into the BIND logging
+// channel it came from.
+type responseCategory int
+
+const (
+	categoryOther responseCategory = iota
+	categoryQuery
+	categoryResponse
+	categoryError
+	categoryRPZ
+)
+
+var (
+	rcodeRegex     = regexp.MustCompile(`(?i)\b(NOERROR|NXDOMAIN|SERVFAIL|REFUSED|FORMERR|NOTIMP)\b`)
+	rpzActionRegex = regexp.MustCompile(`(?i)\brpz (NXDOMAIN|PASSTHRU|DROP|TCP-only|rewritten)\b`)
+)
+
+// classifyResponseLine buckets a raw named log line by which BIND logging
+// channel produced it. dnsclIPaddress/dnsclDomainName only ever look at
+// categoryQuery; dnsclResponses looks at the response, error, and RPZ
+// categories.
+func classifyResponseLine(line string) responseCategory {
+	switch {
+	case !strings.Contains(line, "named"):
+		return categoryOther
+	case strings.Contains(line, "query-errors:"):
+		return categoryError
+	case strings.Contains(line, "rpz"):
+		return categoryRPZ
+	case strings.Contains(line, "response-log:"):
+		return categoryResponse
+	case strings.Contains(line, "query:"):
+		return categoryQuery
+	default:
+		return categoryOther
+	}
+}
+
+// dnsclResponses parses BIND's response-log, query-errors, and RPZ category
+// log lines (rather than the query: lines dnsclIPaddress/dnsclDomainName
+// look at) and reports counts by RCODE and RPZ action, plus the top
+// offending client IPs and top NXDOMAIN'd names. NXDOMAIN names are only
+// captured when the response line embeds the original "query: <name>"
+// token, which BIND includes when query logging and response logging are
+// both enabled.
+func dnsclResponses() int {
+	startTime := time.Now()
+	lineCount := 0
+	rcodeMap := make(map[string]int)
+	rpzActionMap := make(map[string]int)
+	clientMap := make(map[string]int)
+	nxdomainMap := make(map[string]int)
+
+	syslogFile, err := resolveLogSource(logSourceFlag).Open()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	defer func() {
+		if err = syslogFile.Close(); err != nil {
+			log.Fatal(err)
+		}
+	}()
+
+	scanner := bufio.NewScanner(syslogFile)
+	for scanner.Scan() {
+		line := scanner.Text()
+		category := classifyResponseLine(line)
+		if category != categoryRPZ && category != categoryResponse && category != categoryError {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		clientIP := ""
+		for _, field := range fields {
+			if strings.Contains(field, "#") && !strings.HasPrefix(field, "(") {
+				clientIP = strings.Split(field, "#")[0]
+				break
+			}
+		}
+
+		switch category {
+		case categoryRPZ:
+			match := rpzActionRegex.FindStringSubmatch(line)
+			if match == nil {
+				continue
+			}
+			rpzActionMap[strings.ToUpper(match[1])]++
+			if clientIP != "" {
+				clientMap[clientIP]++
+			}
+		case categoryResponse, categoryError:
+			match := rcodeRegex.FindStringSubmatch(line)
+			if match == nil {
+				continue
+			}
+			rcode := strings.ToUpper(match[1])
+			rcodeMap[rcode]++
+			if rcode == "NXDOMAIN" {
+				if clientIP != "" {
+					clientMap[clientIP]++
+				}
+				if event, ok := parser.Parse(line); ok {
+					nxdomainMap[event.QueryName]++
+				}
+			}
+		}
+		lineCount++
+	}
+
+	rcodeMapSorted := sortMap(rcodeMap)
+	rpzActionMapSorted := sortMap(rpzActionMap)
+	clientMapSorted := sortMap(clientMap)
+	nxdomainMapSorted := sortMap(nxdomainMap)
+	elapsedTime := time.Since(startTime).Seconds()
+
+	fmt.Println()
+	fmt.Println("response codes:")
+	for _, rcode := range rcodeMapSorted {
+		fmt.Printf("%v \t %v\n", rcode.Value, rcode.Key)
+	}
+
+	fmt.Println("\nrpz actions:")
+	for _, action := range rpzActionMapSorted {
+		fmt.Printf("%v \t %v\n", action.Value, action.Key)
+	}
+
+	fmt.Println("\ntop offending clients:")
+	for _, client := range clientMapSorted {
+		fmt.Printf("%v \t %v\n", client.Value, client.Key)
+	}
+
+	fmt.Println("\ntop NXDOMAIN names:")
+	for _, name := range nxdomainMapSorted {
+		fmt.Printf("%v \t %v\n", name.Value, name.Key)
+	}
+
+	fmt.Printf("\nSummary: Searched %d response/error/rpz lines.\n", lineCount)
+	fmt.Printf("Query time: %.2f seconds\n", elapsedTime)
+	return lineCount
+}
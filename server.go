@@ -0,0 +1,203 @@
+// server.go: embedded HTTP API exposing the parsed BIND query log
+// author: Mark W. Hunter
+// https://github.com/mark-w-hunter/dnscl
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mark-w-hunter/dnscl/parser"
+)
+
+// defaultServeAddr is the address dnscl serve listens on when none is given.
+const defaultServeAddr = ":8053"
+
+// QueryLogEntry is a single parsed BIND query log line as returned by the
+// /querylog API.
+type QueryLogEntry struct {
+	Timestamp time.Time `json:"timestamp"`
+	ClientIP  string    `json:"client_ip"`
+	QueryName string    `json:"query_name"`
+	QueryType string    `json:"query_type"`
+	Response  string    `json:"response"`
+}
+
+// querylogResponse is the JSON body returned by the /querylog endpoint.
+type querylogResponse struct {
+	Total   int             `json:"total"`
+	Offset  int             `json:"offset"`
+	Limit   int             `json:"limit"`
+	Results []QueryLogEntry `json:"results"`
+}
+
+// dnsclServe starts an HTTP server exposing the parsed query log as JSON.
+func dnsclServe(addr string) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/querylog", queryLogHandler)
+
+	fmt.Println("dnscl serve listening on", addr)
+	return http.ListenAndServe(addr, mux)
+}
+
+// queryLogHandler serves GET /querylog?ip=...&domain=...&older_than=...&offset=...&limit=...&type=...
+func queryLogHandler(w http.ResponseWriter, r *http.Request) {
+	entries, err := loadQueryLogEntries()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	query := r.URL.Query()
+	if ip := query.Get("ip"); ip != "" {
+		entries = filterEntriesByIP(entries, ip)
+	}
+	if domain := query.Get("domain"); domain != "" {
+		entries, err = filterEntriesByDomain(entries, domain)
+		if err != nil {
+			http.Error(w, "invalid domain: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	if queryType := query.Get("type"); queryType != "" {
+		entries = filterEntriesByType(entries, queryType)
+	}
+	if olderThan := query.Get("older_than"); olderThan != "" {
+		cutoff, err := time.Parse(time.RFC3339, olderThan)
+		if err != nil {
+			http.Error(w, "invalid older_than: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		entries = filterEntriesOlderThan(entries, cutoff)
+	}
+
+	sortEntries(entries, query.Get("sort"))
+
+	offset, _ := strconv.Atoi(query.Get("offset"))
+	if offset < 0 {
+		offset = 0
+	}
+	if offset > len(entries) {
+		offset = len(entries)
+	}
+	limit, err := strconv.Atoi(query.Get("limit"))
+	if err != nil || limit <= 0 {
+		limit = 100
+	}
+	end := offset + limit
+	if end > len(entries) {
+		end = len(entries)
+	}
+
+	response := querylogResponse{
+		Total:   len(entries),
+		Offset:  offset,
+		Limit:   limit,
+		Results: entries[offset:end],
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// sortEntries sorts entries in place by time (default) or by query name
+// frequency when by is "count".
+func sortEntries(entries []QueryLogEntry, by string) {
+	switch by {
+	case "count":
+		counts := make(map[string]int)
+		for _, entry := range entries {
+			counts[entry.QueryName]++
+		}
+		sort.SliceStable(entries, func(i, j int) bool {
+			return counts[entries[i].QueryName] > counts[entries[j].QueryName]
+		})
+	default:
+		sort.SliceStable(entries, func(i, j int) bool {
+			return entries[i].Timestamp.Before(entries[j].Timestamp)
+		})
+	}
+}
+
+func filterEntriesByIP(entries []QueryLogEntry, ip string) []QueryLogEntry {
+	filtered := make([]QueryLogEntry, 0, len(entries))
+	for _, entry := range entries {
+		if entry.ClientIP == ip {
+			filtered = append(filtered, entry)
+		}
+	}
+	return filtered
+}
+
+func filterEntriesByDomain(entries []QueryLogEntry, domain string) ([]QueryLogEntry, error) {
+	domainRegex, err := regexp.Compile("(?i)" + domain)
+	if err != nil {
+		return nil, err
+	}
+	filtered := make([]QueryLogEntry, 0, len(entries))
+	for _, entry := range entries {
+		if domainRegex.MatchString(entry.QueryName) {
+			filtered = append(filtered, entry)
+		}
+	}
+	return filtered, nil
+}
+
+func filterEntriesByType(entries []QueryLogEntry, queryType string) []QueryLogEntry {
+	filtered := make([]QueryLogEntry, 0, len(entries))
+	for _, entry := range entries {
+		if strings.EqualFold(entry.QueryType, queryType) {
+			filtered = append(filtered, entry)
+		}
+	}
+	return filtered
+}
+
+func filterEntriesOlderThan(entries []QueryLogEntry, cutoff time.Time) []QueryLogEntry {
+	filtered := make([]QueryLogEntry, 0, len(entries))
+	for _, entry := range entries {
+		if entry.Timestamp.Before(cutoff) {
+			filtered = append(filtered, entry)
+		}
+	}
+	return filtered
+}
+
+// loadQueryLogEntries scans the configured log source and returns every
+// parsed query log entry, applying ignore-view like every other call site.
+func loadQueryLogEntries() ([]QueryLogEntry, error) {
+	syslogFile, err := resolveLogSource(logSourceFlag).Open()
+	if err != nil {
+		return nil, err
+	}
+	defer syslogFile.Close()
+
+	var entries []QueryLogEntry
+	scanner := bufio.NewScanner(syslogFile)
+	for scanner.Scan() {
+		event, ok := parser.Parse(scanner.Text())
+		if !ok || appConfig.ignoresView(event.View) {
+			continue
+		}
+
+		entries = append(entries, QueryLogEntry{
+			Timestamp: event.Time,
+			ClientIP:  event.ClientIP,
+			QueryName: event.QueryName,
+			QueryType: event.QueryType,
+			Response:  event.Flags,
+		})
+	}
+
+	return entries, scanner.Err()
+}